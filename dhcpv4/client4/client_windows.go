@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/insomniacslk/dhcp/dhcpv4"
+	"golang.org/x/net/ipv4"
 )
 
 // MaxUDPReceivedPacketSize is the (arbitrary) maximum UDP packet size supported
@@ -97,12 +98,40 @@ func (c *Client) getRemoteUDPAddr() (*net.UDPAddr, error) {
 	return raddr, nil
 }
 
+// listen opens an ipv4.PacketConn bound to laddr.Port and scoped to
+// ifname via control messages, returning the resolved interface
+// alongside it so callers can tag outgoing packets and filter incoming
+// ones by interface index.
+func (c *Client) listen(ifname string, laddr *net.UDPAddr) (*ipv4.PacketConn, *net.Interface, error) {
+	ifi, err := net.InterfaceByName(ifname)
+	if err != nil {
+		return nil, nil, fmt.Errorf("interface %s not found: %v", ifname, err)
+	}
+
+	// On Windows, we use a standard UDP socket, listening on all
+	// interfaces since we can't bind to a specific one, and filter
+	// incoming/outgoing traffic to ifi via control messages.
+	udpConn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero, Port: laddr.Port})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to listen on UDP: %v", err)
+	}
+
+	conn := ipv4.NewPacketConn(udpConn)
+	if err := conn.SetControlMessage(ipv4.FlagInterface|ipv4.FlagDst|ipv4.FlagSrc, true); err != nil {
+		udpConn.Close()
+		return nil, nil, fmt.Errorf("failed to enable control messages on %s: %v", ifname, err)
+	}
+
+	return conn, ifi, nil
+}
+
 // Exchange runs a full DORA transaction: Discover, Offer, Request, Acknowledge,
 // over UDP. Does not retry in case of failures.
 //
-// On Windows, this uses standard UDP sockets. Note that on Windows,
-// binding to a specific interface is not supported, so the client will
-// listen on all interfaces and filter by transaction ID.
+// On Windows, binding to a specific interface at the socket level is not
+// supported, so the client listens on all interfaces and uses an
+// ipv4.PacketConn with SetControlMessage to scope traffic to ifname,
+// dropping replies that arrived on a different NIC.
 func (c *Client) Exchange(ifname string, modifiers ...dhcpv4.Modifier) ([]*dhcpv4.DHCPv4, error) {
 	conversation := make([]*dhcpv4.DHCPv4, 0)
 	raddr, err := c.getRemoteUDPAddr()
@@ -114,11 +143,9 @@ func (c *Client) Exchange(ifname string, modifiers ...dhcpv4.Modifier) ([]*dhcpv
 		return nil, err
 	}
 
-	// On Windows, we use standard UDP socket
-	// Listen on all interfaces since we can't bind to a specific one
-	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero, Port: laddr.Port})
+	conn, ifi, err := c.listen(ifname, laddr)
 	if err != nil {
-		return conversation, fmt.Errorf("failed to listen on UDP: %v", err)
+		return conversation, err
 	}
 	defer conn.Close()
 
@@ -130,7 +157,7 @@ func (c *Client) Exchange(ifname string, modifiers ...dhcpv4.Modifier) ([]*dhcpv
 	conversation = append(conversation, discover)
 
 	// Offer
-	offer, err := c.sendReceive(conn, discover, raddr, dhcpv4.MessageTypeOffer)
+	offer, err := c.sendReceive(conn, ifi.Index, discover, raddr, dhcpv4.MessageTypeOffer)
 	if err != nil {
 		return conversation, err
 	}
@@ -144,7 +171,7 @@ func (c *Client) Exchange(ifname string, modifiers ...dhcpv4.Modifier) ([]*dhcpv
 	conversation = append(conversation, request)
 
 	// Ack
-	ack, err := c.sendReceive(conn, request, raddr, dhcpv4.MessageTypeAck)
+	ack, err := c.sendReceive(conn, ifi.Index, request, raddr, dhcpv4.MessageTypeAck)
 	if err != nil {
 		return conversation, err
 	}
@@ -153,15 +180,159 @@ func (c *Client) Exchange(ifname string, modifiers ...dhcpv4.Modifier) ([]*dhcpv
 	return conversation, nil
 }
 
-// sendReceive sends a DHCP packet and waits for a response
-func (c *Client) sendReceive(conn *net.UDPConn, packet *dhcpv4.DHCPv4, raddr *net.UDPAddr, messageType dhcpv4.MessageType) (*dhcpv4.DHCPv4, error) {
+// Inform builds a DHCPINFORM for a host that already has ciaddr
+// configured, unicasts it to the server on ifname (falling back to
+// broadcast if no RemoteAddr is set), and returns the ACK carrying
+// configuration options only. Unlike Exchange, it never touches the
+// client's IP assignment, which is the common case for config-only
+// refresh on an already-addressed host such as a VM.
+func (c *Client) Inform(ifname string, ciaddr net.IP, modifiers ...dhcpv4.Modifier) (*dhcpv4.DHCPv4, error) {
+	raddr, err := c.getRemoteUDPAddr()
+	if err != nil {
+		return nil, err
+	}
+	laddr, err := c.getLocalUDPAddr()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, ifi, err := c.listen(ifname, laddr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	inform, err := dhcpv4.NewInform(ifi.HardwareAddr, ciaddr, modifiers...)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.sendReceive(conn, ifi.Index, inform, raddr, dhcpv4.MessageTypeAck)
+}
+
+// Renew unicasts a REQUEST for lease to the server identifier recorded in
+// its ACK, from ciaddr:68 as RFC 2131 requires for renewal, and returns
+// the refreshed ACK. Unlike Exchange, it does not rerun DISCOVER, so it
+// only works once lease has already been obtained through one.
+//
+// Per RFC 2131 §4.3.2's state table, a RENEWING REQUEST carries neither
+// a requested-IP-address nor a server-identifier option — ciaddr alone
+// conveys the address being renewed — so this builds the packet
+// directly instead of repurposing the SELECTING-state
+// NewRequestFromOffer constructor.
+func (c *Client) Renew(ifname string, lease *dhcpv4.DHCPv4, modifiers ...dhcpv4.Modifier) (*dhcpv4.DHCPv4, error) {
+	serverID := lease.ServerIdentifier()
+	if serverID == nil {
+		return nil, errors.New("lease has no server identifier to renew against")
+	}
+
+	laddr, err := c.getLocalUDPAddr()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, ifi, err := c.listen(ifname, laddr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	request, err := dhcpv4.New(append([]dhcpv4.Modifier{
+		dhcpv4.WithHwAddr(lease.ClientHWAddr),
+		dhcpv4.WithMessageType(dhcpv4.MessageTypeRequest),
+		dhcpv4.WithClientIP(lease.YourIPAddr),
+	}, modifiers...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	raddr := &net.UDPAddr{IP: serverID, Port: dhcpv4.ServerPort}
+	return c.sendReceive(conn, ifi.Index, request, raddr, dhcpv4.MessageTypeAck)
+}
+
+// Release unicasts a RELEASE for lease to the server identifier recorded
+// in its ACK, notifying it that ciaddr is no longer in use. Like a real
+// RELEASE, no reply is expected from the server.
+func (c *Client) Release(ifname string, lease *dhcpv4.DHCPv4, modifiers ...dhcpv4.Modifier) error {
+	serverID := lease.ServerIdentifier()
+	if serverID == nil {
+		return errors.New("lease has no server identifier to release to")
+	}
+
+	laddr, err := c.getLocalUDPAddr()
+	if err != nil {
+		return err
+	}
+
+	conn, ifi, err := c.listen(ifname, laddr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	release, err := dhcpv4.NewRequestFromOffer(lease, append([]dhcpv4.Modifier{dhcpv4.WithMessageType(dhcpv4.MessageTypeRelease)}, modifiers...)...)
+	if err != nil {
+		return err
+	}
+	release.ClientIPAddr = lease.YourIPAddr
+
+	raddr := &net.UDPAddr{IP: serverID, Port: dhcpv4.ServerPort}
+	return c.send(conn, ifi.Index, release, raddr)
+}
+
+// Decline broadcasts a DECLINE for offer, telling the server that the
+// address it offered is already in use on the LAN (as discovered by, for
+// example, an ARP probe) and must not be offered again. Like a real
+// DECLINE, no reply is expected from the server.
+func (c *Client) Decline(ifname string, offer *dhcpv4.DHCPv4, modifiers ...dhcpv4.Modifier) error {
+	raddr, err := c.getRemoteUDPAddr()
+	if err != nil {
+		return err
+	}
+	laddr, err := c.getLocalUDPAddr()
+	if err != nil {
+		return err
+	}
+
+	conn, ifi, err := c.listen(ifname, laddr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	decline, err := dhcpv4.NewRequestFromOffer(offer, append([]dhcpv4.Modifier{dhcpv4.WithMessageType(dhcpv4.MessageTypeDecline)}, modifiers...)...)
+	if err != nil {
+		return err
+	}
+
+	return c.send(conn, ifi.Index, decline, raddr)
+}
+
+// send writes packet out over conn, tagging it with ifIndex so it leaves
+// via the correct NIC on multi-homed hosts, without waiting for a reply.
+func (c *Client) send(conn *ipv4.PacketConn, ifIndex int, packet *dhcpv4.DHCPv4, raddr *net.UDPAddr) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(c.WriteTimeout)); err != nil {
+		return err
+	}
+	cm := &ipv4.ControlMessage{IfIndex: ifIndex}
+	if _, err := conn.WriteTo(packet.ToBytes(), cm, raddr); err != nil {
+		return fmt.Errorf("failed to send DHCP packet: %v", err)
+	}
+	return nil
+}
+
+// sendReceive sends a DHCP packet and waits for a response arriving on
+// ifIndex, tagging the outgoing packet with the same interface so it
+// leaves via the correct NIC on multi-homed hosts.
+func (c *Client) sendReceive(conn *ipv4.PacketConn, ifIndex int, packet *dhcpv4.DHCPv4, raddr *net.UDPAddr, messageType dhcpv4.MessageType) (*dhcpv4.DHCPv4, error) {
 	// Set write deadline
 	if err := conn.SetWriteDeadline(time.Now().Add(c.WriteTimeout)); err != nil {
 		return nil, err
 	}
 
 	// Send the packet
-	if _, err := conn.WriteTo(packet.ToBytes(), raddr); err != nil {
+	cm := &ipv4.ControlMessage{IfIndex: ifIndex}
+	if _, err := conn.WriteTo(packet.ToBytes(), cm, raddr); err != nil {
 		return nil, fmt.Errorf("failed to send DHCP packet: %v", err)
 	}
 
@@ -173,11 +344,16 @@ func (c *Client) sendReceive(conn *net.UDPConn, packet *dhcpv4.DHCPv4, raddr *ne
 	// Receive response
 	for {
 		buf := make([]byte, MaxUDPReceivedPacketSize)
-		n, _, err := conn.ReadFromUDP(buf)
+		n, cm, _, err := conn.ReadFrom(buf)
 		if err != nil {
 			return nil, fmt.Errorf("failed to receive DHCP response: %v", err)
 		}
 
+		// Drop packets that arrived on a different interface.
+		if cm != nil && cm.IfIndex != ifIndex {
+			continue
+		}
+
 		response, err := dhcpv4.FromBytes(buf[:n])
 		if err != nil {
 			log.Printf("Error parsing DHCPv4 response: %v", err)
@@ -11,6 +11,8 @@ import (
 	"fmt"
 	"net"
 	"time"
+
+	"golang.org/x/net/ipv4"
 )
 
 var (
@@ -25,33 +27,47 @@ var (
 	ErrUDPAddrIsRequired = errors.New("must supply UDPAddr")
 )
 
-// WindowsUDPConn wraps a standard UDP connection for Windows
+// WindowsUDPConn wraps a standard UDP connection for Windows, scoping
+// reads and writes to a single interface via ipv4.PacketConn control
+// messages since Windows has no equivalent of SO_BINDTODEVICE.
 type WindowsUDPConn struct {
 	conn      *net.UDPConn
+	pconn     *ipv4.PacketConn
 	boundAddr *net.UDPAddr
+	ifIndex   int
 }
 
-// NewRawUDPConn returns a UDP connection bound to the port.
-// On Windows, we cannot bind to a specific interface, so we listen on all interfaces.
+// NewRawUDPConn returns a UDP connection bound to the port and scoped to
+// the named interface.
 //
-// The interface parameter is ignored on Windows.
+// Windows has no socket-level equivalent of SO_BINDTODEVICE, so instead
+// we listen on all interfaces and use an ipv4.PacketConn with
+// SetControlMessage to learn which interface each packet arrived on
+// (and to tag outgoing packets with the interface to send from), dropping
+// anything that doesn't match iface.
 func NewRawUDPConn(iface string, port int) (net.PacketConn, error) {
-	// Verify interface exists (for error reporting)
-	_, err := net.InterfaceByName(iface)
+	ifi, err := net.InterfaceByName(iface)
 	if err != nil {
 		return nil, fmt.Errorf("interface %s not found: %v", iface, err)
 	}
 
-	// Use standard UDP socket on Windows - listen on all interfaces
 	addr := &net.UDPAddr{IP: net.IPv4zero, Port: port}
 	conn, err := net.ListenUDP("udp4", addr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to listen on port %d: %v", port, err)
 	}
 
+	pconn := ipv4.NewPacketConn(conn)
+	if err := pconn.SetControlMessage(ipv4.FlagInterface|ipv4.FlagDst|ipv4.FlagSrc, true); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to enable control messages on %s: %v", iface, err)
+	}
+
 	return &WindowsUDPConn{
 		conn:      conn,
+		pconn:     pconn,
 		boundAddr: addr,
+		ifIndex:   ifi.Index,
 	}, nil
 }
 
@@ -70,22 +86,31 @@ type BroadcastRawUDPConn struct {
 	boundAddr *net.UDPAddr
 }
 
-// ReadFrom implements net.PacketConn.ReadFrom
+// ReadFrom implements net.PacketConn.ReadFrom. It discards any packet that
+// did not arrive on the interface WindowsUDPConn was created for.
 func (w *WindowsUDPConn) ReadFrom(b []byte) (int, net.Addr, error) {
-	n, addr, err := w.conn.ReadFromUDP(b)
-	if err != nil {
-		return 0, nil, err
+	for {
+		n, cm, addr, err := w.pconn.ReadFrom(b)
+		if err != nil {
+			return 0, nil, err
+		}
+		if cm != nil && cm.IfIndex != w.ifIndex {
+			continue
+		}
+		return n, addr, nil
 	}
-	return n, addr, nil
 }
 
-// WriteTo implements net.PacketConn.WriteTo
+// WriteTo implements net.PacketConn.WriteTo. It tags the outgoing packet
+// with the bound interface so replies leave the correct NIC on
+// multi-homed hosts.
 func (w *WindowsUDPConn) WriteTo(b []byte, addr net.Addr) (int, error) {
 	udpAddr, ok := addr.(*net.UDPAddr)
 	if !ok {
 		return 0, ErrUDPAddrIsRequired
 	}
-	return w.conn.WriteTo(b, udpAddr)
+	cm := &ipv4.ControlMessage{IfIndex: w.ifIndex}
+	return w.pconn.WriteTo(b, cm, udpAddr)
 }
 
 // Close implements net.PacketConn.Close
@@ -0,0 +1,58 @@
+// Copyright 2018 the u-root Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nclient4
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+)
+
+// Exchange runs a full DISCOVER/OFFER/REQUEST/ACK exchange on ifname over
+// t (or DefaultTransport, if t is nil), and returns the OFFER and ACK it
+// received. Unlike CheckOtherServer, Inform and Renew, it accepts a
+// Transport explicitly so tests can drive it over an in-memory network
+// such as vnet.Network instead of a real socket.
+func Exchange(ctx context.Context, t Transport, ifname string, timeout time.Duration, modifiers ...dhcpv4.Modifier) (offer, ack *dhcpv4.DHCPv4, err error) {
+	if t == nil {
+		t = DefaultTransport
+	}
+
+	ifi, err := t.InterfaceByName(ifname)
+	if err != nil {
+		return nil, nil, fmt.Errorf("interface %s not found: %w", ifname, err)
+	}
+
+	conn, err := t.ListenBroadcast(ifname, dhcpv4.ClientPort)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open socket on %s: %w", ifname, err)
+	}
+	defer conn.Close()
+
+	discover, err := dhcpv4.NewDiscovery(ifi.HardwareAddr, append([]dhcpv4.Modifier{dhcpv4.WithBroadcast(true)}, modifiers...)...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build DHCPDISCOVER: %w", err)
+	}
+
+	raddr := &net.UDPAddr{IP: net.IPv4bcast, Port: dhcpv4.ServerPort}
+	offer, err = sendReceive(ctx, conn, discover, raddr, dhcpv4.MessageTypeOffer, timeout)
+	if err != nil {
+		return nil, nil, fmt.Errorf("DISCOVER failed: %w", err)
+	}
+
+	request, err := dhcpv4.NewRequestFromOffer(offer, append([]dhcpv4.Modifier{dhcpv4.WithBroadcast(true)}, modifiers...)...)
+	if err != nil {
+		return offer, nil, fmt.Errorf("failed to build REQUEST: %w", err)
+	}
+
+	ack, err = sendReceive(ctx, conn, request, raddr, dhcpv4.MessageTypeAck, timeout)
+	if err != nil {
+		return offer, nil, fmt.Errorf("REQUEST failed: %w", err)
+	}
+	return offer, ack, nil
+}
@@ -0,0 +1,182 @@
+// Copyright 2018 the u-root Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nclient4
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+)
+
+// Inform builds a DHCPINFORM for a host that already has ciaddr
+// configured on ifname, unicasts it to the server on serverAddr:67 (or
+// broadcasts it if serverAddr is nil), and returns the ACK carrying
+// configuration options only. Unlike a full DORA exchange, it never
+// touches the client's IP assignment, which is the common case for
+// config-only refresh on an already-addressed host such as a VM.
+func Inform(ctx context.Context, ifname string, ciaddr net.IP, serverAddr net.IP, timeout time.Duration, modifiers ...dhcpv4.Modifier) (*dhcpv4.DHCPv4, error) {
+	ifi, err := net.InterfaceByName(ifname)
+	if err != nil {
+		return nil, fmt.Errorf("interface %s not found: %w", ifname, err)
+	}
+
+	conn, err := NewRawUDPConn(ifname, dhcpv4.ClientPort)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open socket on %s: %w", ifname, err)
+	}
+	defer conn.Close()
+
+	inform, err := dhcpv4.NewInform(ifi.HardwareAddr, ciaddr, modifiers...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build DHCPINFORM: %w", err)
+	}
+
+	raddr := &net.UDPAddr{IP: net.IPv4bcast, Port: dhcpv4.ServerPort}
+	if serverAddr != nil {
+		raddr = &net.UDPAddr{IP: serverAddr, Port: dhcpv4.ServerPort}
+	}
+
+	return sendReceive(ctx, conn, inform, raddr, dhcpv4.MessageTypeAck, timeout)
+}
+
+// Renew unicasts a REQUEST for lease to the server identifier recorded in
+// its ACK, from ciaddr:68 as RFC 2131 requires for renewal, and returns
+// the refreshed ACK. Unlike a full DORA exchange, it does not rerun
+// DISCOVER, so it only works once lease has already been obtained
+// through one.
+//
+// Per RFC 2131 §4.3.2's state table, a RENEWING REQUEST carries neither
+// a requested-IP-address nor a server-identifier option — ciaddr alone
+// conveys the address being renewed — so this builds the packet
+// directly instead of repurposing the SELECTING-state
+// NewRequestFromOffer constructor.
+func Renew(ctx context.Context, ifname string, lease *dhcpv4.DHCPv4, timeout time.Duration, modifiers ...dhcpv4.Modifier) (*dhcpv4.DHCPv4, error) {
+	serverID := lease.ServerIdentifier()
+	if serverID == nil {
+		return nil, errors.New("lease has no server identifier to renew against")
+	}
+
+	conn, err := NewRawUDPConn(ifname, dhcpv4.ClientPort)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open socket on %s: %w", ifname, err)
+	}
+	defer conn.Close()
+
+	request, err := dhcpv4.New(append([]dhcpv4.Modifier{
+		dhcpv4.WithHwAddr(lease.ClientHWAddr),
+		dhcpv4.WithMessageType(dhcpv4.MessageTypeRequest),
+		dhcpv4.WithClientIP(lease.YourIPAddr),
+	}, modifiers...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build REQUEST: %w", err)
+	}
+
+	raddr := &net.UDPAddr{IP: serverID, Port: dhcpv4.ServerPort}
+	return sendReceive(ctx, conn, request, raddr, dhcpv4.MessageTypeAck, timeout)
+}
+
+// Release unicasts a RELEASE for lease to the server identifier recorded
+// in its ACK, notifying it that the lease's address is no longer in use.
+// Like a real RELEASE, no reply is expected from the server.
+func Release(ifname string, lease *dhcpv4.DHCPv4, modifiers ...dhcpv4.Modifier) error {
+	serverID := lease.ServerIdentifier()
+	if serverID == nil {
+		return errors.New("lease has no server identifier to release to")
+	}
+
+	conn, err := NewRawUDPConn(ifname, dhcpv4.ClientPort)
+	if err != nil {
+		return fmt.Errorf("failed to open socket on %s: %w", ifname, err)
+	}
+	defer conn.Close()
+
+	release, err := dhcpv4.NewRequestFromOffer(lease, append([]dhcpv4.Modifier{dhcpv4.WithMessageType(dhcpv4.MessageTypeRelease)}, modifiers...)...)
+	if err != nil {
+		return fmt.Errorf("failed to build RELEASE: %w", err)
+	}
+	release.ClientIPAddr = lease.YourIPAddr
+
+	raddr := &net.UDPAddr{IP: serverID, Port: dhcpv4.ServerPort}
+	if _, err := conn.WriteTo(release.ToBytes(), raddr); err != nil {
+		return fmt.Errorf("failed to send RELEASE: %w", err)
+	}
+	return nil
+}
+
+// Decline broadcasts a DECLINE for offer, telling the server that the
+// address it offered is already in use on the LAN (as discovered by, for
+// example, an ARP probe) and must not be offered again. Like a real
+// DECLINE, no reply is expected from the server.
+func Decline(ifname string, offer *dhcpv4.DHCPv4, modifiers ...dhcpv4.Modifier) error {
+	conn, err := NewRawUDPConn(ifname, dhcpv4.ClientPort)
+	if err != nil {
+		return fmt.Errorf("failed to open socket on %s: %w", ifname, err)
+	}
+	defer conn.Close()
+
+	decline, err := dhcpv4.NewRequestFromOffer(offer, append([]dhcpv4.Modifier{dhcpv4.WithMessageType(dhcpv4.MessageTypeDecline)}, modifiers...)...)
+	if err != nil {
+		return fmt.Errorf("failed to build DECLINE: %w", err)
+	}
+
+	raddr := &net.UDPAddr{IP: net.IPv4bcast, Port: dhcpv4.ServerPort}
+	if _, err := conn.WriteTo(decline.ToBytes(), raddr); err != nil {
+		return fmt.Errorf("failed to send DECLINE: %w", err)
+	}
+	return nil
+}
+
+// sendReceive sends packet to raddr over conn and waits for a reply of
+// messageType with a matching transaction ID, honoring both ctx and
+// timeout.
+func sendReceive(ctx context.Context, conn net.PacketConn, packet *dhcpv4.DHCPv4, raddr *net.UDPAddr, messageType dhcpv4.MessageType, timeout time.Duration) (*dhcpv4.DHCPv4, error) {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	if err := conn.SetWriteDeadline(deadline); err != nil {
+		return nil, err
+	}
+	if _, err := conn.WriteTo(packet.ToBytes(), raddr); err != nil {
+		return nil, fmt.Errorf("failed to send %s: %w", packet.MessageType(), err)
+	}
+
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, maxProbeReceivedPacketSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to receive DHCP response: %w", err)
+		}
+
+		response, err := dhcpv4.FromBytes(buf[:n])
+		if err != nil {
+			continue
+		}
+		if response.TransactionID != packet.TransactionID {
+			continue
+		}
+		if response.OpCode != dhcpv4.OpcodeBootReply {
+			continue
+		}
+		if messageType != dhcpv4.MessageTypeNone && response.MessageType() != messageType {
+			continue
+		}
+
+		return response, nil
+	}
+}
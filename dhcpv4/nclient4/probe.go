@@ -0,0 +1,93 @@
+// Copyright 2018 the u-root Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nclient4
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+)
+
+// maxProbeReceivedPacketSize is the largest DHCPv4 packet CheckOtherServer
+// expects to receive while probing for another server.
+const maxProbeReceivedPacketSize = 8192
+
+// CheckOtherServer probes ifname for an existing DHCPv4 server on the LAN,
+// the same check AdGuardHome performs before enabling its own DHCP server:
+// broadcast a DHCPDISCOVER and see whether anything answers with a valid
+// OFFER before timeout elapses. It works unmodified on Linux, BSD/Darwin
+// and Windows, since it is built entirely on top of NewRawUDPConn.
+//
+// found is true if a matching OFFER was received; serverID carries that
+// OFFER's OptionServerIdentifier, if present.
+func CheckOtherServer(ctx context.Context, ifname string, timeout time.Duration) (found bool, serverID net.IP, err error) {
+	ifi, err := net.InterfaceByName(ifname)
+	if err != nil {
+		return false, nil, fmt.Errorf("interface %s not found: %w", ifname, err)
+	}
+
+	conn, err := NewRawUDPConn(ifname, dhcpv4.ClientPort)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to open socket on %s: %w", ifname, err)
+	}
+	defer conn.Close()
+
+	return checkOtherServer(ctx, conn, ifi.HardwareAddr, timeout)
+}
+
+func checkOtherServer(ctx context.Context, conn net.PacketConn, hwaddr net.HardwareAddr, timeout time.Duration) (bool, net.IP, error) {
+	discover, err := dhcpv4.NewDiscovery(hwaddr, dhcpv4.WithBroadcast(true))
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to build DHCPDISCOVER: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	raddr := &net.UDPAddr{IP: net.IPv4bcast, Port: dhcpv4.ServerPort}
+	if err := conn.SetWriteDeadline(deadline); err != nil {
+		return false, nil, err
+	}
+	if _, err := conn.WriteTo(discover.ToBytes(), raddr); err != nil {
+		return false, nil, fmt.Errorf("failed to broadcast DHCPDISCOVER: %w", err)
+	}
+
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return false, nil, err
+	}
+
+	buf := make([]byte, maxProbeReceivedPacketSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return false, nil, nil
+		}
+
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				return false, nil, nil
+			}
+			return false, nil, err
+		}
+
+		offer, err := dhcpv4.FromBytes(buf[:n])
+		if err != nil {
+			continue
+		}
+		if offer.TransactionID != discover.TransactionID {
+			continue
+		}
+		if offer.OpCode != dhcpv4.OpcodeBootReply || offer.MessageType() != dhcpv4.MessageTypeOffer {
+			continue
+		}
+
+		return true, offer.ServerIdentifier(), nil
+	}
+}
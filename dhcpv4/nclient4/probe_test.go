@@ -0,0 +1,132 @@
+// Copyright 2018 the u-root Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nclient4
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+)
+
+// pipePacketConn is a minimal in-memory net.PacketConn, used in pairs to
+// fake a link between a probing client and a fake DHCP server without
+// needing a real socket.
+type pipePacketConn struct {
+	addr     net.Addr
+	in       chan []byte
+	out      chan []byte
+	readDead time.Time
+}
+
+func newPipePacketConnPair() (*pipePacketConn, *pipePacketConn) {
+	ab := make(chan []byte, 4)
+	ba := make(chan []byte, 4)
+	a := &pipePacketConn{addr: &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 68}, in: ba, out: ab}
+	b := &pipePacketConn{addr: &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 67}, in: ab, out: ba}
+	return a, b
+}
+
+func (p *pipePacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	var after <-chan time.Time
+	if !p.readDead.IsZero() {
+		if d := time.Until(p.readDead); d > 0 {
+			after = time.After(d)
+		} else {
+			after = time.After(0)
+		}
+	}
+	select {
+	case msg := <-p.in:
+		return copy(b, msg), p.addr, nil
+	case <-after:
+		return 0, nil, &net.OpError{Op: "read", Err: errTimeout{}}
+	}
+}
+
+func (p *pipePacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	msg := make([]byte, len(b))
+	copy(msg, b)
+	p.out <- msg
+	return len(b), nil
+}
+
+func (p *pipePacketConn) Close() error         { return nil }
+func (p *pipePacketConn) LocalAddr() net.Addr  { return p.addr }
+func (p *pipePacketConn) SetDeadline(t time.Time) error { return p.SetReadDeadline(t) }
+
+func (p *pipePacketConn) SetReadDeadline(t time.Time) error {
+	p.readDead = t
+	return nil
+}
+
+func (p *pipePacketConn) SetWriteDeadline(t time.Time) error { return nil }
+
+type errTimeout struct{}
+
+func (errTimeout) Error() string   { return "i/o timeout" }
+func (errTimeout) Timeout() bool   { return true }
+func (errTimeout) Temporary() bool { return true }
+
+func TestCheckOtherServerFound(t *testing.T) {
+	client, server := newPipePacketConnPair()
+	hwaddr := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0x00, 0x01}
+	serverID := net.IPv4(192, 168, 1, 1)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, maxProbeReceivedPacketSize)
+		n, addr, err := server.ReadFrom(buf)
+		if err != nil {
+			t.Errorf("server ReadFrom: %v", err)
+			return
+		}
+		discover, err := dhcpv4.FromBytes(buf[:n])
+		if err != nil {
+			t.Errorf("server failed to parse DHCPDISCOVER: %v", err)
+			return
+		}
+		offer, err := dhcpv4.NewReplyFromRequest(discover,
+			dhcpv4.WithMessageType(dhcpv4.MessageTypeOffer),
+			dhcpv4.WithServerIP(serverID),
+			dhcpv4.WithOption(dhcpv4.OptServerIdentifier(serverID)),
+		)
+		if err != nil {
+			t.Errorf("failed to build OFFER: %v", err)
+			return
+		}
+		if _, err := server.WriteTo(offer.ToBytes(), addr); err != nil {
+			t.Errorf("server WriteTo: %v", err)
+		}
+	}()
+
+	found, gotServerID, err := checkOtherServer(context.Background(), client, hwaddr, time.Second)
+	<-done
+	if err != nil {
+		t.Fatalf("checkOtherServer returned error: %v", err)
+	}
+	if !found {
+		t.Fatal("expected checkOtherServer to find a server, got none")
+	}
+	if !gotServerID.Equal(serverID) {
+		t.Errorf("got server ID %s, want %s", gotServerID, serverID)
+	}
+}
+
+func TestCheckOtherServerNotFound(t *testing.T) {
+	client, _ := newPipePacketConnPair()
+	hwaddr := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0x00, 0x02}
+
+	found, serverID, err := checkOtherServer(context.Background(), client, hwaddr, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("checkOtherServer returned error: %v", err)
+	}
+	if found {
+		t.Fatalf("expected no server to be found, got serverID %s", serverID)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2018 the u-root Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nclient4
+
+import "net"
+
+// Transport abstracts the network operations nclient4 needs to perform a
+// DHCP exchange, so callers can plug in something other than the
+// std-net-backed, OS-specific default: an in-memory virtual network for
+// tests (see internal/xsocket/vnet), or a userspace TCP/IP stack such as
+// gVisor/netstack.
+type Transport interface {
+	// ListenBroadcast opens a PacketConn bound to port on the named
+	// interface that can send and receive broadcast traffic.
+	ListenBroadcast(ifname string, port int) (net.PacketConn, error)
+
+	// Interfaces lists the network interfaces visible to this transport.
+	Interfaces() ([]net.Interface, error)
+
+	// InterfaceByName looks up a single interface by name.
+	InterfaceByName(ifname string) (*net.Interface, error)
+}
+
+// DefaultTransport is the Transport nclient4 uses when none is supplied
+// explicitly. It is backed by the standard library and delegates to the
+// platform-specific NewRawUDPConn implementation for this OS.
+var DefaultTransport Transport = stdTransport{}
+
+type stdTransport struct{}
+
+func (stdTransport) ListenBroadcast(ifname string, port int) (net.PacketConn, error) {
+	return NewRawUDPConn(ifname, port)
+}
+
+func (stdTransport) Interfaces() ([]net.Interface, error) {
+	return net.Interfaces()
+}
+
+func (stdTransport) InterfaceByName(ifname string) (*net.Interface, error) {
+	return net.InterfaceByName(ifname)
+}
+
+// NewRawUDPConnWithTransport is like NewRawUDPConn, but sources its socket
+// from t instead of the OS-specific default. Passing a nil Transport is
+// equivalent to calling NewRawUDPConn directly.
+func NewRawUDPConnWithTransport(t Transport, iface string, port int) (net.PacketConn, error) {
+	if t == nil {
+		t = DefaultTransport
+	}
+	return t.ListenBroadcast(iface, port)
+}
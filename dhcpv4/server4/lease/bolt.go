@@ -0,0 +1,176 @@
+package lease
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+var leaseBucket = []byte("leases")
+
+// BoltStore is a LeaseStore backed by a bbolt database file, for
+// deployments that want crash-safe persistence without running a
+// separate database service.
+type BoltStore struct {
+	db   *bbolt.DB
+	pool *Pool
+}
+
+// NewBoltStore opens (creating if necessary) a bbolt database at path
+// and returns a LeaseStore backed by it and pool.
+func NewBoltStore(pool *Pool, path string) (*BoltStore, error) {
+	db, err := bbolt.Open(path, 0o600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("lease: failed to open bbolt db %s: %w", path, err)
+	}
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(leaseBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("lease: failed to create lease bucket in %s: %w", path, err)
+	}
+	return &BoltStore{db: db, pool: pool}, nil
+}
+
+// Close releases the underlying bbolt database.
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *BoltStore) get(tx *bbolt.Tx, mac net.HardwareAddr) (*Lease, bool) {
+	v := tx.Bucket(leaseBucket).Get([]byte(mac.String()))
+	if v == nil {
+		return nil, false
+	}
+	var r leaseRecord
+	if err := json.Unmarshal(v, &r); err != nil {
+		return nil, false
+	}
+	return &Lease{MAC: mac, IP: net.ParseIP(r.IP), Expiry: r.Expiry, Hostname: r.Hostname}, true
+}
+
+func (s *BoltStore) put(tx *bbolt.Tx, l *Lease) error {
+	data, err := json.Marshal(leaseRecord{
+		MAC:      l.MAC.String(),
+		IP:       l.IP.String(),
+		Expiry:   l.Expiry,
+		Hostname: l.Hostname,
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(leaseBucket).Put([]byte(l.MAC.String()), data)
+}
+
+// Get implements LeaseStore.
+func (s *BoltStore) Get(mac net.HardwareAddr) (*Lease, bool) {
+	var l *Lease
+	var ok bool
+	s.db.View(func(tx *bbolt.Tx) error {
+		l, ok = s.get(tx, mac)
+		return nil
+	})
+	return l, ok
+}
+
+// Allocate implements LeaseStore.
+func (s *BoltStore) Allocate(mac net.HardwareAddr, requested net.IP) (*Lease, error) {
+	var result *Lease
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		now := time.Now()
+		if l, ok := s.get(tx, mac); ok {
+			if !l.Expired(now) {
+				result = l
+				return nil
+			}
+			if err := tx.Bucket(leaseBucket).Delete([]byte(mac.String())); err != nil {
+				return err
+			}
+		}
+
+		inUse := make(map[string]bool)
+		c := tx.Bucket(leaseBucket).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var r leaseRecord
+			if json.Unmarshal(v, &r) != nil {
+				continue
+			}
+			if !r.Expiry.IsZero() && now.After(r.Expiry) {
+				// Reclaim the address: this lease has lapsed.
+				if err := c.Delete(); err != nil {
+					return err
+				}
+				continue
+			}
+			inUse[r.IP] = true
+		}
+
+		ip := requested
+		if _, reserved := s.pool.Reserved(mac); reserved || ip == nil || !s.pool.InRange(ip) ||
+			inUse[ip.String()] || reservedTo(s.pool.cfg.Reservations, ip) {
+			var err error
+			ip, err = s.pool.Next(mac, inUse)
+			if err != nil {
+				return err
+			}
+		}
+
+		result = &Lease{MAC: mac, IP: ip, Expiry: now.Add(s.pool.cfg.LeaseTime)}
+		return s.put(tx, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Renew implements LeaseStore.
+func (s *BoltStore) Renew(mac net.HardwareAddr) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		l, ok := s.get(tx, mac)
+		if !ok {
+			return fmt.Errorf("lease: no lease for %s to renew", mac)
+		}
+		l.Expiry = time.Now().Add(s.pool.cfg.LeaseTime)
+		return s.put(tx, l)
+	})
+}
+
+// Release implements LeaseStore.
+func (s *BoltStore) Release(mac net.HardwareAddr) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(leaseBucket).Delete([]byte(mac.String()))
+	})
+}
+
+// Range implements LeaseStore.
+func (s *BoltStore) Range() iter.Seq[*Lease] {
+	return func(yield func(*Lease) bool) {
+		var leases []*Lease
+		s.db.View(func(tx *bbolt.Tx) error {
+			c := tx.Bucket(leaseBucket).Cursor()
+			for k, v := c.First(); k != nil; k, v = c.Next() {
+				var r leaseRecord
+				if json.Unmarshal(v, &r) != nil {
+					continue
+				}
+				mac, err := net.ParseMAC(r.MAC)
+				if err != nil {
+					continue
+				}
+				leases = append(leases, &Lease{MAC: mac, IP: net.ParseIP(r.IP), Expiry: r.Expiry, Hostname: r.Hostname})
+			}
+			return nil
+		})
+		for _, l := range leases {
+			if !yield(l) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Package lease implements a server4 lease store and IP pool allocator,
+// so a Handler built on server4.WithLeaseHandler tracks MAC identity and
+// RENEW/RELEASE correctly instead of reimplementing state tracking with
+// an incrementing counter the way the dhcpserver4-test example previously
+// did.
+package lease
+
+import (
+	"iter"
+	"net"
+	"time"
+)
+
+// Lease is a single MAC-to-IP binding handed out by a Pool.
+type Lease struct {
+	MAC      net.HardwareAddr
+	IP       net.IP
+	Expiry   time.Time
+	Hostname string
+}
+
+// Expired reports whether the lease's expiry time has passed as of now.
+func (l *Lease) Expired(now time.Time) bool {
+	return !l.Expiry.IsZero() && now.After(l.Expiry)
+}
+
+// LeaseStore tracks the MAC-to-IP bindings a Pool hands out across
+// DISCOVER/REQUEST/RELEASE/DECLINE, so a Handler built on top of it
+// survives restarts and gives repeat clients a consistent answer.
+type LeaseStore interface {
+	// Get returns the current lease for mac, if any.
+	Get(mac net.HardwareAddr) (*Lease, bool)
+
+	// Allocate returns mac's existing lease if it has one, or otherwise
+	// hands out and records a new one, preferring requested if it is
+	// free and in range.
+	Allocate(mac net.HardwareAddr, requested net.IP) (*Lease, error)
+
+	// Renew extends mac's existing lease's expiry. It returns an error
+	// if mac has no current lease.
+	Renew(mac net.HardwareAddr) error
+
+	// Release frees mac's lease, if any, making its IP available again.
+	Release(mac net.HardwareAddr) error
+
+	// Range iterates over every lease currently held by the store.
+	Range() iter.Seq[*Lease]
+}
@@ -0,0 +1,161 @@
+package lease
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func testPool(t *testing.T) *Pool {
+	t.Helper()
+	mac := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0x00, 0x0a}
+	p, err := NewPool(PoolConfig{
+		ServerIP:   net.IPv4(192, 168, 1, 1),
+		RangeStart: net.IPv4(192, 168, 1, 100),
+		RangeEnd:   net.IPv4(192, 168, 1, 101),
+		LeaseTime:  time.Hour,
+		Reservations: map[string]net.IP{
+			mac.String(): net.IPv4(192, 168, 1, 50),
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+	return p
+}
+
+func TestMemoryStoreAllocateIsStableAndExhausts(t *testing.T) {
+	s, err := NewMemoryStore(testPool(t), "", 0)
+	if err != nil {
+		t.Fatalf("NewMemoryStore: %v", err)
+	}
+
+	mac1 := net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x01}
+	mac2 := net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x02}
+	mac3 := net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x03}
+
+	l1, err := s.Allocate(mac1, nil)
+	if err != nil {
+		t.Fatalf("Allocate(mac1): %v", err)
+	}
+	l1Again, err := s.Allocate(mac1, nil)
+	if err != nil {
+		t.Fatalf("Allocate(mac1) again: %v", err)
+	}
+	if !l1Again.IP.Equal(l1.IP) {
+		t.Errorf("repeat Allocate(mac1) returned %s, want stable %s", l1Again.IP, l1.IP)
+	}
+
+	if _, err := s.Allocate(mac2, nil); err != nil {
+		t.Fatalf("Allocate(mac2): %v", err)
+	}
+	if _, err := s.Allocate(mac3, nil); err == nil {
+		t.Fatal("expected pool exhaustion error allocating a third dynamic lease from a 2-address range")
+	}
+}
+
+func TestMemoryStoreHonorsReservation(t *testing.T) {
+	s, err := NewMemoryStore(testPool(t), "", 0)
+	if err != nil {
+		t.Fatalf("NewMemoryStore: %v", err)
+	}
+
+	reservedMAC := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0x00, 0x0a}
+	l, err := s.Allocate(reservedMAC, nil)
+	if err != nil {
+		t.Fatalf("Allocate: %v", err)
+	}
+	want := net.IPv4(192, 168, 1, 50)
+	if !l.IP.Equal(want) {
+		t.Errorf("got IP %s for reserved MAC, want %s", l.IP, want)
+	}
+}
+
+func TestMemoryStoreReleaseFreesAddress(t *testing.T) {
+	s, err := NewMemoryStore(testPool(t), "", 0)
+	if err != nil {
+		t.Fatalf("NewMemoryStore: %v", err)
+	}
+
+	mac1 := net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x01}
+	mac2 := net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x02}
+	mac3 := net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x03}
+
+	if _, err := s.Allocate(mac1, nil); err != nil {
+		t.Fatalf("Allocate(mac1): %v", err)
+	}
+	if _, err := s.Allocate(mac2, nil); err != nil {
+		t.Fatalf("Allocate(mac2): %v", err)
+	}
+	if err := s.Release(mac1); err != nil {
+		t.Fatalf("Release(mac1): %v", err)
+	}
+	if _, ok := s.Get(mac1); ok {
+		t.Error("expected Get(mac1) to report no lease after Release")
+	}
+	if _, err := s.Allocate(mac3, nil); err != nil {
+		t.Fatalf("Allocate(mac3) after releasing mac1's address: %v", err)
+	}
+}
+
+func TestMemoryStoreAllocateReclaimsExpiredLease(t *testing.T) {
+	s, err := NewMemoryStore(testPool(t), "", 0)
+	if err != nil {
+		t.Fatalf("NewMemoryStore: %v", err)
+	}
+
+	mac1 := net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x01}
+	mac2 := net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x02}
+	mac3 := net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x03}
+
+	l1, err := s.Allocate(mac1, nil)
+	if err != nil {
+		t.Fatalf("Allocate(mac1): %v", err)
+	}
+	if _, err := s.Allocate(mac2, nil); err != nil {
+		t.Fatalf("Allocate(mac2): %v", err)
+	}
+	if _, err := s.Allocate(mac3, nil); err == nil {
+		t.Fatal("expected pool exhaustion error allocating a third dynamic lease from a 2-address range")
+	}
+
+	// mac1's lease has lapsed; a fresh allocation for any MAC should
+	// reclaim its address instead of reporting the pool exhausted.
+	l1.Expiry = time.Now().Add(-time.Minute)
+
+	l3, err := s.Allocate(mac3, nil)
+	if err != nil {
+		t.Fatalf("Allocate(mac3) after mac1's lease expired: %v", err)
+	}
+	if !l3.IP.Equal(l1.IP) {
+		t.Errorf("got reclaimed IP %s, want mac1's expired address %s", l3.IP, l1.IP)
+	}
+	if _, ok := s.Get(mac1); ok {
+		t.Error("expected Get(mac1) to report no lease once it expired and was reclaimed")
+	}
+}
+
+func TestMemoryStoreRenewRequiresExistingLease(t *testing.T) {
+	s, err := NewMemoryStore(testPool(t), "", 0)
+	if err != nil {
+		t.Fatalf("NewMemoryStore: %v", err)
+	}
+
+	mac := net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x01}
+	if err := s.Renew(mac); err == nil {
+		t.Fatal("expected Renew to fail for a MAC with no lease")
+	}
+
+	if _, err := s.Allocate(mac, nil); err != nil {
+		t.Fatalf("Allocate: %v", err)
+	}
+	before, _ := s.Get(mac)
+	beforeExpiry := before.Expiry
+	if err := s.Renew(mac); err != nil {
+		t.Fatalf("Renew: %v", err)
+	}
+	after, _ := s.Get(mac)
+	if !after.Expiry.After(beforeExpiry) && !after.Expiry.Equal(beforeExpiry) {
+		t.Errorf("Renew did not extend expiry: before %v, after %v", beforeExpiry, after.Expiry)
+	}
+}
@@ -0,0 +1,204 @@
+package lease
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// leaseRecord is the on-disk/on-wire representation of a Lease, kept
+// separate from Lease itself so MAC/IP round-trip through their string
+// forms instead of relying on net.HardwareAddr/net.IP's own (un)marshaling.
+type leaseRecord struct {
+	MAC      string    `json:"mac"`
+	IP       string    `json:"ip"`
+	Expiry   time.Time `json:"expiry"`
+	Hostname string    `json:"hostname,omitempty"`
+}
+
+// MemoryStore is an in-memory LeaseStore that periodically snapshots its
+// state to a JSON file, so leases survive a server restart. The
+// snapshot's layout mirrors the AdGuardHome dhcpd lease-file convention
+// (one record per lease, keyed by MAC) for interop with its tooling.
+type MemoryStore struct {
+	mu       sync.Mutex
+	pool     *Pool
+	leases   map[string]*Lease // keyed by mac.String()
+	snapshot string
+}
+
+// NewMemoryStore creates a MemoryStore backed by pool, loading any
+// existing leases from snapshotPath if it exists. If snapshotInterval is
+// positive, a background goroutine writes a fresh snapshot to
+// snapshotPath on that interval; otherwise callers must call Save
+// explicitly. Leaving snapshotPath empty disables persistence entirely.
+func NewMemoryStore(pool *Pool, snapshotPath string, snapshotInterval time.Duration) (*MemoryStore, error) {
+	s := &MemoryStore{
+		pool:     pool,
+		leases:   make(map[string]*Lease),
+		snapshot: snapshotPath,
+	}
+	if snapshotPath != "" {
+		if err := s.load(); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("lease: failed to load snapshot %s: %w", snapshotPath, err)
+		}
+	}
+	if snapshotInterval > 0 && snapshotPath != "" {
+		go s.snapshotLoop(snapshotInterval)
+	}
+	return s, nil
+}
+
+func (s *MemoryStore) snapshotLoop(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for range t.C {
+		// Best-effort: a failed snapshot shouldn't crash the server,
+		// only lose the most recent lease changes.
+		_ = s.Save()
+	}
+}
+
+func (s *MemoryStore) load() error {
+	data, err := os.ReadFile(s.snapshot)
+	if err != nil {
+		return err
+	}
+	var records []leaseRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range records {
+		mac, err := net.ParseMAC(r.MAC)
+		if err != nil {
+			continue
+		}
+		s.leases[mac.String()] = &Lease{
+			MAC:      mac,
+			IP:       net.ParseIP(r.IP),
+			Expiry:   r.Expiry,
+			Hostname: r.Hostname,
+		}
+	}
+	return nil
+}
+
+// Save writes the current lease set to the configured snapshot path. It
+// is a no-op if no snapshot path was configured.
+func (s *MemoryStore) Save() error {
+	if s.snapshot == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	records := make([]leaseRecord, 0, len(s.leases))
+	for _, l := range s.leases {
+		records = append(records, leaseRecord{
+			MAC:      l.MAC.String(),
+			IP:       l.IP.String(),
+			Expiry:   l.Expiry,
+			Hostname: l.Hostname,
+		})
+	}
+	s.mu.Unlock()
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.snapshot, data, 0o644)
+}
+
+// Get implements LeaseStore.
+func (s *MemoryStore) Get(mac net.HardwareAddr) (*Lease, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.leases[mac.String()]
+	return l, ok
+}
+
+// Allocate implements LeaseStore.
+func (s *MemoryStore) Allocate(mac net.HardwareAddr, requested net.IP) (*Lease, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if l, ok := s.leases[mac.String()]; ok {
+		if !l.Expired(now) {
+			return l, nil
+		}
+		delete(s.leases, mac.String())
+	}
+
+	inUse := make(map[string]bool, len(s.leases))
+	for k, l := range s.leases {
+		if l.Expired(now) {
+			delete(s.leases, k)
+			continue
+		}
+		inUse[l.IP.String()] = true
+	}
+
+	ip := requested
+	if _, reserved := s.pool.Reserved(mac); reserved || ip == nil || !s.pool.InRange(ip) ||
+		inUse[ip.String()] || reservedTo(s.pool.cfg.Reservations, ip) {
+		var err error
+		ip, err = s.pool.Next(mac, inUse)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	l := &Lease{
+		MAC:    mac,
+		IP:     ip,
+		Expiry: now.Add(s.pool.cfg.LeaseTime),
+	}
+	s.leases[mac.String()] = l
+	return l, nil
+}
+
+// Renew implements LeaseStore.
+func (s *MemoryStore) Renew(mac net.HardwareAddr) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.leases[mac.String()]
+	if !ok {
+		return fmt.Errorf("lease: no lease for %s to renew", mac)
+	}
+	l.Expiry = time.Now().Add(s.pool.cfg.LeaseTime)
+	return nil
+}
+
+// Release implements LeaseStore.
+func (s *MemoryStore) Release(mac net.HardwareAddr) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.leases, mac.String())
+	return nil
+}
+
+// Range implements LeaseStore.
+func (s *MemoryStore) Range() iter.Seq[*Lease] {
+	return func(yield func(*Lease) bool) {
+		s.mu.Lock()
+		leases := make([]*Lease, 0, len(s.leases))
+		for _, l := range s.leases {
+			leases = append(leases, l)
+		}
+		s.mu.Unlock()
+
+		for _, l := range leases {
+			if !yield(l) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package lease
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// PoolConfig describes the address range a Pool allocates from and any
+// static reservations by MAC address.
+type PoolConfig struct {
+	// ServerIP is this server's own address, used by handlers built on
+	// the pool to reject REQUESTs addressed to a different server.
+	ServerIP net.IP
+
+	// RangeStart and RangeEnd bound the dynamically allocatable
+	// addresses, inclusive.
+	RangeStart, RangeEnd net.IP
+
+	// LeaseTime is how long a dynamically allocated lease remains
+	// valid before it must be renewed.
+	LeaseTime time.Duration
+
+	// Reservations maps a MAC address string (net.HardwareAddr.String())
+	// to a static IP that MAC always receives, bypassing RangeStart/
+	// RangeEnd.
+	Reservations map[string]net.IP
+}
+
+// Pool allocates IPv4 addresses out of a PoolConfig's range, honoring
+// static reservations and avoiding addresses reported already in use.
+type Pool struct {
+	cfg PoolConfig
+}
+
+// NewPool validates cfg and returns a Pool that allocates from it.
+func NewPool(cfg PoolConfig) (*Pool, error) {
+	start := cfg.RangeStart.To4()
+	end := cfg.RangeEnd.To4()
+	if start == nil || end == nil {
+		return nil, fmt.Errorf("lease: RangeStart and RangeEnd must both be IPv4 addresses")
+	}
+	if ip2int(end) < ip2int(start) {
+		return nil, fmt.Errorf("lease: RangeEnd %s is before RangeStart %s", cfg.RangeEnd, cfg.RangeStart)
+	}
+	return &Pool{cfg: cfg}, nil
+}
+
+// Reserved returns the static IP reserved for mac, if any.
+func (p *Pool) Reserved(mac net.HardwareAddr) (net.IP, bool) {
+	ip, ok := p.cfg.Reservations[mac.String()]
+	return ip, ok
+}
+
+// InRange reports whether ip falls within the pool's dynamic range.
+func (p *Pool) InRange(ip net.IP) bool {
+	v4 := ip.To4()
+	if v4 == nil {
+		return false
+	}
+	n := ip2int(v4)
+	return n >= ip2int(p.cfg.RangeStart.To4()) && n <= ip2int(p.cfg.RangeEnd.To4())
+}
+
+// Next returns mac's static reservation if it has one, or else the first
+// address in the pool's dynamic range that is neither reserved for a
+// different MAC nor reported in use by inUse.
+func (p *Pool) Next(mac net.HardwareAddr, inUse map[string]bool) (net.IP, error) {
+	if ip, ok := p.Reserved(mac); ok {
+		return ip, nil
+	}
+
+	start := ip2int(p.cfg.RangeStart.To4())
+	end := ip2int(p.cfg.RangeEnd.To4())
+	for n := start; n <= end; n++ {
+		ip := int2ip(n)
+		if reservedTo(p.cfg.Reservations, ip) || inUse[ip.String()] {
+			continue
+		}
+		return ip, nil
+	}
+	return nil, fmt.Errorf("lease: no free address in %s-%s", p.cfg.RangeStart, p.cfg.RangeEnd)
+}
+
+func reservedTo(reservations map[string]net.IP, ip net.IP) bool {
+	for _, reserved := range reservations {
+		if reserved.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func ip2int(ip net.IP) uint32 {
+	if ip == nil {
+		return 0
+	}
+	return uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3])
+}
+
+func int2ip(n uint32) net.IP {
+	return net.IPv4(byte(n>>24), byte(n>>16), byte(n>>8), byte(n)).To4()
+}
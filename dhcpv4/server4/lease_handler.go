@@ -0,0 +1,159 @@
+package server4
+
+import (
+	"log"
+	"net"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/insomniacslk/dhcp/dhcpv4/server4/lease"
+)
+
+// WithLeaseHandler builds a Handler implementing the full DISCOVER/
+// REQUEST/DECLINE/RELEASE/INFORM state machine against store and cfg,
+// and installs it on the Server, replacing the broken incrementing
+// allocator the dhcpserver4-test example previously open-coded.
+func WithLeaseHandler(store lease.LeaseStore, cfg lease.PoolConfig) ServerOpt {
+	pool, poolErr := lease.NewPool(cfg)
+	h := &leaseHandler{store: store, pool: pool, cfg: cfg}
+	return func(s *Server) error {
+		if poolErr != nil {
+			return poolErr
+		}
+		h.server = s
+		s.Handler = h.handle
+		return nil
+	}
+}
+
+type leaseHandler struct {
+	store  lease.LeaseStore
+	pool   *lease.Pool
+	cfg    lease.PoolConfig
+	server *Server
+}
+
+// reply sends resp in answer to req via SendReply, so replies follow RFC
+// 2131 §4.1's destination-selection rules instead of always unicasting
+// back to peer, which misbehaves whenever the client's kernel hasn't yet
+// plumbed the address it was just offered.
+func (h *leaseHandler) reply(conn net.PacketConn, req, resp *dhcpv4.DHCPv4) error {
+	return SendReply(conn, &h.server.Iface, req, resp)
+}
+
+func (h *leaseHandler) handle(conn net.PacketConn, peer net.Addr, m *dhcpv4.DHCPv4) {
+	switch m.MessageType() {
+	case dhcpv4.MessageTypeDiscover:
+		h.discover(conn, peer, m)
+	case dhcpv4.MessageTypeRequest:
+		h.request(conn, peer, m)
+	case dhcpv4.MessageTypeDecline, dhcpv4.MessageTypeRelease:
+		if err := h.store.Release(m.ClientHWAddr); err != nil {
+			log.Printf("lease: failed to release lease for %s: %v", m.ClientHWAddr, err)
+		}
+	case dhcpv4.MessageTypeInform:
+		h.inform(conn, peer, m)
+	}
+}
+
+func (h *leaseHandler) discover(conn net.PacketConn, peer net.Addr, m *dhcpv4.DHCPv4) {
+	l, err := h.store.Allocate(m.ClientHWAddr, m.RequestedIPAddress())
+	if err != nil {
+		log.Printf("lease: failed to allocate for %s: %v", m.ClientHWAddr, err)
+		return
+	}
+
+	resp, err := dhcpv4.NewReplyFromRequest(m,
+		dhcpv4.WithMessageType(dhcpv4.MessageTypeOffer),
+		dhcpv4.WithServerIP(h.cfg.ServerIP),
+		dhcpv4.WithYourIP(l.IP),
+		dhcpv4.WithLeaseTime(uint32(h.cfg.LeaseTime.Seconds())),
+	)
+	if err != nil {
+		log.Printf("lease: failed to build OFFER for %s: %v", m.ClientHWAddr, err)
+		return
+	}
+	if err := h.reply(conn, m, resp); err != nil {
+		log.Printf("lease: failed to send OFFER to %s: %v", peer, err)
+	}
+}
+
+func (h *leaseHandler) request(conn net.PacketConn, peer net.Addr, m *dhcpv4.DHCPv4) {
+	if serverID := m.ServerIdentifier(); serverID != nil && h.cfg.ServerIP != nil && !serverID.Equal(h.cfg.ServerIP) {
+		// This REQUEST selected a different server's OFFER; stay silent.
+		return
+	}
+
+	requested := m.RequestedIPAddress()
+	if requested == nil {
+		requested = m.ClientIPAddr
+	}
+
+	nak := requested == nil || !h.pool.InRange(requested)
+	if !nak {
+		if reserved, ok := h.pool.Reserved(m.ClientHWAddr); ok && !reserved.Equal(requested) {
+			nak = true
+		}
+	}
+
+	if nak {
+		h.nak(conn, peer, m)
+		return
+	}
+
+	l, ok := h.store.Get(m.ClientHWAddr)
+	if ok && l.IP.Equal(requested) {
+		if err := h.store.Renew(m.ClientHWAddr); err != nil {
+			log.Printf("lease: failed to renew lease for %s: %v", m.ClientHWAddr, err)
+		}
+	} else {
+		var err error
+		l, err = h.store.Allocate(m.ClientHWAddr, requested)
+		if err != nil {
+			log.Printf("lease: failed to allocate %s for %s: %v", requested, m.ClientHWAddr, err)
+			h.nak(conn, peer, m)
+			return
+		}
+	}
+
+	resp, err := dhcpv4.NewReplyFromRequest(m,
+		dhcpv4.WithMessageType(dhcpv4.MessageTypeAck),
+		dhcpv4.WithServerIP(h.cfg.ServerIP),
+		dhcpv4.WithYourIP(l.IP),
+		dhcpv4.WithLeaseTime(uint32(h.cfg.LeaseTime.Seconds())),
+	)
+	if err != nil {
+		log.Printf("lease: failed to build ACK for %s: %v", m.ClientHWAddr, err)
+		return
+	}
+	if err := h.reply(conn, m, resp); err != nil {
+		log.Printf("lease: failed to send ACK to %s: %v", peer, err)
+	}
+}
+
+func (h *leaseHandler) nak(conn net.PacketConn, peer net.Addr, m *dhcpv4.DHCPv4) {
+	resp, err := dhcpv4.NewReplyFromRequest(m,
+		dhcpv4.WithMessageType(dhcpv4.MessageTypeNak),
+		dhcpv4.WithServerIP(h.cfg.ServerIP),
+	)
+	if err != nil {
+		log.Printf("lease: failed to build NAK for %s: %v", m.ClientHWAddr, err)
+		return
+	}
+	if err := h.reply(conn, m, resp); err != nil {
+		log.Printf("lease: failed to send NAK to %s: %v", peer, err)
+	}
+}
+
+func (h *leaseHandler) inform(conn net.PacketConn, peer net.Addr, m *dhcpv4.DHCPv4) {
+	resp, err := dhcpv4.NewReplyFromRequest(m,
+		dhcpv4.WithMessageType(dhcpv4.MessageTypeAck),
+		dhcpv4.WithServerIP(h.cfg.ServerIP),
+	)
+	if err != nil {
+		log.Printf("lease: failed to build ACK for INFORM from %s: %v", m.ClientHWAddr, err)
+		return
+	}
+	if err := h.reply(conn, m, resp); err != nil {
+		log.Printf("lease: failed to send ACK to %s: %v", peer, err)
+	}
+}
@@ -0,0 +1,102 @@
+package server4
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/insomniacslk/dhcp/dhcpv4/server4/lease"
+)
+
+// recordingConn is a net.PacketConn that only records what the last
+// WriteTo sent, enough to inspect a handler's reply without a real socket.
+type recordingConn struct {
+	sent []byte
+	to   net.Addr
+}
+
+func (c *recordingConn) ReadFrom(b []byte) (int, net.Addr, error) { return 0, nil, io.EOF }
+
+func (c *recordingConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	c.sent = append([]byte(nil), b...)
+	c.to = addr
+	return len(b), nil
+}
+
+func (c *recordingConn) Close() error                    { return nil }
+func (c *recordingConn) LocalAddr() net.Addr              { return nil }
+func (c *recordingConn) SetDeadline(time.Time) error      { return nil }
+func (c *recordingConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *recordingConn) SetWriteDeadline(time.Time) error { return nil }
+
+func testHandler(t *testing.T) (*leaseHandler, lease.PoolConfig) {
+	t.Helper()
+	cfg := lease.PoolConfig{
+		ServerIP:   net.IPv4(192, 168, 1, 1),
+		RangeStart: net.IPv4(192, 168, 1, 100),
+		RangeEnd:   net.IPv4(192, 168, 1, 101),
+		LeaseTime:  time.Hour,
+	}
+	pool, err := lease.NewPool(cfg)
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+	store, err := lease.NewMemoryStore(pool, "", 0)
+	if err != nil {
+		t.Fatalf("NewMemoryStore: %v", err)
+	}
+	h := &leaseHandler{
+		store:  store,
+		pool:   pool,
+		cfg:    cfg,
+		server: &Server{Iface: net.Interface{Name: "eth0"}},
+	}
+	return h, cfg
+}
+
+// TestLeaseHandlerRequestUsesStoreAllocatedIP covers the case where a MAC
+// already holds a lease and its REQUEST asks for a different, still
+// in-range address: Allocate's documented contract is to return the
+// existing lease unchanged, and the ACK must reflect that lease's actual
+// IP rather than blindly echoing back whatever the client requested.
+func TestLeaseHandlerRequestUsesStoreAllocatedIP(t *testing.T) {
+	h, cfg := testHandler(t)
+
+	mac := net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x01}
+	existing, err := h.store.Allocate(mac, net.IPv4(192, 168, 1, 100))
+	if err != nil {
+		t.Fatalf("Allocate: %v", err)
+	}
+
+	offer, err := dhcpv4.NewReplyFromRequest(&dhcpv4.DHCPv4{ClientHWAddr: mac},
+		dhcpv4.WithMessageType(dhcpv4.MessageTypeOffer),
+		dhcpv4.WithServerIP(cfg.ServerIP),
+		dhcpv4.WithYourIP(net.IPv4(192, 168, 1, 101)),
+	)
+	if err != nil {
+		t.Fatalf("NewReplyFromRequest: %v", err)
+	}
+	req, err := dhcpv4.NewRequestFromOffer(offer, dhcpv4.WithBroadcast(true))
+	if err != nil {
+		t.Fatalf("NewRequestFromOffer: %v", err)
+	}
+
+	conn := &recordingConn{}
+	h.request(conn, &net.UDPAddr{IP: net.IPv4bcast, Port: dhcpv4.ClientPort}, req)
+
+	if conn.sent == nil {
+		t.Fatal("handler sent no reply")
+	}
+	resp, err := dhcpv4.FromBytes(conn.sent)
+	if err != nil {
+		t.Fatalf("FromBytes(reply): %v", err)
+	}
+	if resp.MessageType() != dhcpv4.MessageTypeAck {
+		t.Fatalf("got message type %s, want ACK", resp.MessageType())
+	}
+	if !resp.YourIPAddr.Equal(existing.IP) {
+		t.Errorf("ACK YourIPAddr = %s, want the store's actual lease %s (not the raw requested address)", resp.YourIPAddr, existing.IP)
+	}
+}
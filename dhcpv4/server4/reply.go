@@ -0,0 +1,54 @@
+package server4
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+)
+
+// SendReply sends resp in answer to req following RFC 2131 §4.1's
+// destination-selection rules, instead of always unicasting to peer (the
+// source address of req) the way Server's default loop previously did,
+// which misbehaves whenever the client's kernel hasn't yet plumbed the
+// address it was just offered:
+//
+//   - if req carries a gateway address (giaddr), unicast to giaddr:67,
+//     since a relay agent is handling delivery to the client itself
+//   - else if req carries a client address (ciaddr), unicast to
+//     ciaddr:68
+//   - else if req's broadcast flag is set, or resp is a NAK, broadcast
+//     to 255.255.255.255:68
+//   - else unicast to the client's hardware address at resp.YourIPAddr,
+//     without ARP, since the client has no IP yet to ARP from
+//
+// The last case needs a raw Ethernet frame on Linux/BSD (sent via an
+// AF_PACKET socket on Linux and a BPF device on BSD) since there is no
+// route to an address the client hasn't configured yet; on Windows it
+// falls back to broadcasting instead.
+func SendReply(conn net.PacketConn, iface *net.Interface, req, resp *dhcpv4.DHCPv4) error {
+	switch {
+	case !isUnspecified(req.GatewayIPAddr):
+		return sendUDP(conn, &net.UDPAddr{IP: req.GatewayIPAddr, Port: dhcpv4.ServerPort}, resp)
+
+	case !isUnspecified(req.ClientIPAddr):
+		return sendUDP(conn, &net.UDPAddr{IP: req.ClientIPAddr, Port: dhcpv4.ClientPort}, resp)
+
+	case req.IsBroadcast() || resp.MessageType() == dhcpv4.MessageTypeNak:
+		return sendUDP(conn, &net.UDPAddr{IP: net.IPv4bcast, Port: dhcpv4.ClientPort}, resp)
+
+	default:
+		return sendUnicastByMAC(conn, iface, req.ClientHWAddr, resp)
+	}
+}
+
+func isUnspecified(ip net.IP) bool {
+	return ip == nil || ip.IsUnspecified()
+}
+
+func sendUDP(conn net.PacketConn, addr *net.UDPAddr, resp *dhcpv4.DHCPv4) error {
+	if _, err := conn.WriteTo(resp.ToBytes(), addr); err != nil {
+		return fmt.Errorf("server4: failed to send reply to %s: %w", addr, err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+//go:build darwin || freebsd || netbsd || openbsd
+
+package server4
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"unsafe"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"golang.org/x/sys/unix"
+)
+
+// sendUnicastByMAC writes a raw Ethernet+IPv4+UDP frame addressed to
+// dstMAC out a BPF device bound to iface, BSD's equivalent of Linux's
+// AF_PACKET for injecting a complete link-layer frame.
+func sendUnicastByMAC(conn net.PacketConn, iface *net.Interface, dstMAC net.HardwareAddr, resp *dhcpv4.DHCPv4) error {
+	bpfFd, bpfPath, err := openBPFDevice()
+	if err != nil {
+		return fmt.Errorf("server4: failed to open a BPF device: %w", err)
+	}
+	defer unix.Close(bpfFd)
+
+	ifreq := make([]byte, 32)
+	copy(ifreq, iface.Name)
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(bpfFd), unix.BIOCSETIF, uintptr(unsafe.Pointer(&ifreq[0]))); errno != 0 {
+		return fmt.Errorf("server4: BIOCSETIF %s on %s: %w", iface.Name, bpfPath, errno)
+	}
+
+	frame, err := buildUnicastFrame(iface.HardwareAddr, dstMAC, resp)
+	if err != nil {
+		return err
+	}
+
+	if _, err := unix.Write(bpfFd, frame); err != nil {
+		return fmt.Errorf("server4: failed to send unicast reply to %s via %s: %w", dstMAC, bpfPath, err)
+	}
+	return nil
+}
+
+// openBPFDevice opens the first available /dev/bpfN device, since BPF
+// devices are exclusive-open and there is no way to know in advance
+// which index is free.
+func openBPFDevice() (fd int, path string, err error) {
+	for i := 0; i < 256; i++ {
+		path = fmt.Sprintf("/dev/bpf%d", i)
+		fd, err = unix.Open(path, unix.O_RDWR, 0)
+		if err == nil {
+			return fd, path, nil
+		}
+		if !os.IsNotExist(err) && err != unix.EBUSY {
+			return -1, "", err
+		}
+	}
+	return -1, "", fmt.Errorf("no free /dev/bpfN device found")
+}
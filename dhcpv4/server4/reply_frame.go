@@ -0,0 +1,94 @@
+//go:build !windows
+
+package server4
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+)
+
+const ethPIP = 0x0800
+const ipProtoUDP = 17
+
+func htons(v uint16) uint16 {
+	return (v<<8)&0xff00 | (v>>8)&0xff
+}
+
+// buildUnicastFrame crafts a complete Ethernet+IPv4+UDP frame carrying
+// resp, addressed to dstMAC at resp.YourIPAddr, bypassing routing and
+// ARP entirely since the client has no IP assigned yet to resolve dstMAC
+// from.
+func buildUnicastFrame(srcMAC, dstMAC net.HardwareAddr, resp *dhcpv4.DHCPv4) ([]byte, error) {
+	dstIP := resp.YourIPAddr.To4()
+	if dstIP == nil {
+		return nil, fmt.Errorf("server4: reply has no YourIPAddr to unicast to")
+	}
+	srcIP := resp.ServerIPAddr.To4()
+	if srcIP == nil {
+		srcIP = net.IPv4zero.To4()
+	}
+
+	payload := resp.ToBytes()
+	udp := make([]byte, 8+len(payload))
+	binary.BigEndian.PutUint16(udp[0:2], uint16(dhcpv4.ServerPort))
+	binary.BigEndian.PutUint16(udp[2:4], uint16(dhcpv4.ClientPort))
+	binary.BigEndian.PutUint16(udp[4:6], uint16(len(udp)))
+	copy(udp[8:], payload)
+	binary.BigEndian.PutUint16(udp[6:8], udpv4Checksum(srcIP, dstIP, udp))
+
+	ip := make([]byte, 20)
+	ip[0] = 0x45 // version 4, IHL 5 words
+	binary.BigEndian.PutUint16(ip[2:4], uint16(len(ip)+len(udp)))
+	ip[8] = 64 // TTL
+	ip[9] = ipProtoUDP
+	copy(ip[12:16], srcIP)
+	copy(ip[16:20], dstIP)
+	binary.BigEndian.PutUint16(ip[10:12], ipv4Checksum(ip))
+
+	eth := make([]byte, 14)
+	copy(eth[0:6], dstMAC)
+	copy(eth[6:12], srcMAC)
+	binary.BigEndian.PutUint16(eth[12:14], ethPIP)
+
+	frame := make([]byte, 0, len(eth)+len(ip)+len(udp))
+	frame = append(frame, eth...)
+	frame = append(frame, ip...)
+	frame = append(frame, udp...)
+	return frame, nil
+}
+
+// ipv4Checksum computes the Internet checksum (RFC 1071) of b, which
+// must have its own checksum field zeroed.
+func ipv4Checksum(b []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(b[i])<<8 | uint32(b[i+1])
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = (sum & 0xffff) + (sum >> 16)
+	}
+	return ^uint16(sum)
+}
+
+func udpv4Checksum(srcIP, dstIP net.IP, udp []byte) uint16 {
+	pseudo := make([]byte, 12+len(udp))
+	copy(pseudo[0:4], srcIP)
+	copy(pseudo[4:8], dstIP)
+	pseudo[9] = ipProtoUDP
+	binary.BigEndian.PutUint16(pseudo[10:12], uint16(len(udp)))
+	copy(pseudo[12:], udp)
+
+	sum := ipv4Checksum(pseudo)
+	if sum == 0 {
+		// A zero UDP checksum means "no checksum" on the wire; fold to
+		// the reserved all-ones value to keep this one meaningful.
+		return 0xffff
+	}
+	return sum
+}
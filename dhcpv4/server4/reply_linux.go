@@ -0,0 +1,38 @@
+//go:build linux
+
+package server4
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"golang.org/x/sys/unix"
+)
+
+// sendUnicastByMAC writes a raw Ethernet+IPv4+UDP frame addressed to
+// dstMAC out an AF_PACKET socket bound to iface.
+func sendUnicastByMAC(conn net.PacketConn, iface *net.Interface, dstMAC net.HardwareAddr, resp *dhcpv4.DHCPv4) error {
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW, int(htons(unix.ETH_P_IP)))
+	if err != nil {
+		return fmt.Errorf("server4: failed to open AF_PACKET socket: %w", err)
+	}
+	defer unix.Close(fd)
+
+	frame, err := buildUnicastFrame(iface.HardwareAddr, dstMAC, resp)
+	if err != nil {
+		return err
+	}
+
+	addr := unix.SockaddrLinklayer{
+		Protocol: htons(unix.ETH_P_IP),
+		Ifindex:  iface.Index,
+		Halen:    6,
+	}
+	copy(addr.Addr[:], dstMAC)
+
+	if err := unix.Sendto(fd, frame, 0, &addr); err != nil {
+		return fmt.Errorf("server4: failed to send unicast reply to %s: %w", dstMAC, err)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package server4
+
+import (
+	"net"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+)
+
+// sendUnicastByMAC has no raw-Ethernet path on Windows without an extra
+// packet-capture driver (e.g. Npcap) that this package does not depend
+// on, so it falls back to broadcasting the reply instead, which every
+// DHCP client already knows how to receive.
+func sendUnicastByMAC(conn net.PacketConn, iface *net.Interface, dstMAC net.HardwareAddr, resp *dhcpv4.DHCPv4) error {
+	return sendUDP(conn, &net.UDPAddr{IP: net.IPv4bcast, Port: dhcpv4.ClientPort}, resp)
+}
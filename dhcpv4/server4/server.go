@@ -0,0 +1,125 @@
+// Package server4 implements a DHCPv4 server.
+package server4
+
+import (
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+)
+
+// Handler handles an incoming DHCPv4 packet m received from peer (the
+// request's source address) and optionally sends responses via conn.
+type Handler func(conn net.PacketConn, peer net.Addr, m *dhcpv4.DHCPv4)
+
+// ServerOpt configures a Server being built by NewServer.
+type ServerOpt func(s *Server) error
+
+// Server listens for and responds to DHCPv4 requests on a single
+// interface.
+type Server struct {
+	Conn    net.PacketConn
+	Handler Handler
+	Iface   net.Interface
+
+	transport Transport
+}
+
+// NewServer creates a Server listening on ifname, invoking handler for
+// every request it receives. opts are applied in order, so a later
+// WithTransport/WithLeaseHandler overrides an earlier one.
+//
+// If laddr is nil or laddr.Port is 0, the server listens on
+// dhcpv4.ServerPort (67).
+func NewServer(ifname string, laddr *net.UDPAddr, handler Handler, opts ...ServerOpt) (*Server, error) {
+	s := &Server{
+		Handler:   handler,
+		transport: DefaultTransport,
+	}
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+
+	ifi, err := s.transport.InterfaceByName(ifname)
+	if err != nil {
+		return nil, fmt.Errorf("server4: interface %s not found: %w", ifname, err)
+	}
+	s.Iface = *ifi
+
+	port := dhcpv4.ServerPort
+	if laddr != nil && laddr.Port != 0 {
+		port = laddr.Port
+	}
+	conn, err := s.transport.ListenBroadcast(ifname, port)
+	if err != nil {
+		return nil, fmt.Errorf("server4: failed to listen on %s: %w", ifname, err)
+	}
+	s.Conn = conn
+
+	return s, nil
+}
+
+// Serve blocks, reading and dispatching requests to s.Handler, until
+// reading from s.Conn fails (including because it was closed).
+func (s *Server) Serve() error {
+	defer s.Conn.Close()
+
+	buf := make([]byte, 4096)
+	for {
+		n, peer, err := s.Conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+
+		m, err := dhcpv4.FromBytes(buf[:n])
+		if err != nil {
+			log.Printf("server4: failed to parse request from %s: %v", peer, err)
+			continue
+		}
+		if s.Handler != nil {
+			s.Handler(s.Conn, peer, m)
+		}
+	}
+}
+
+// Close releases the Server's underlying connection, causing a blocked
+// Serve call to return.
+func (s *Server) Close() error {
+	return s.Conn.Close()
+}
+
+// WithSummaryLogger logs a one-line summary of every request the
+// Server's Handler is invoked with.
+func WithSummaryLogger() ServerOpt {
+	return func(s *Server) error {
+		inner := s.Handler
+		s.Handler = func(conn net.PacketConn, peer net.Addr, m *dhcpv4.DHCPv4) {
+			log.Printf("Received %s from %s", m.Summary(), peer)
+			if inner != nil {
+				inner(conn, peer, m)
+			}
+		}
+		return nil
+	}
+}
+
+// NewRawUDPConn returns the std-net-backed PacketConn stdTransport uses
+// by default: a UDP socket listening on port across all interfaces.
+//
+// Like nclient4's Windows path, this does not bind to ifname at the
+// socket level; ifname is only validated to exist. Callers that need
+// traffic scoped to a single interface (e.g. a multi-homed host) should
+// supply a Transport via WithTransport instead.
+func NewRawUDPConn(ifname string, port int) (net.PacketConn, error) {
+	if _, err := net.InterfaceByName(ifname); err != nil {
+		return nil, fmt.Errorf("interface %s not found: %w", ifname, err)
+	}
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero, Port: port})
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on port %d: %w", port, err)
+	}
+	return conn, nil
+}
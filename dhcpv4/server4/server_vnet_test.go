@@ -0,0 +1,66 @@
+package server4_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/insomniacslk/dhcp/dhcpv4/nclient4"
+	"github.com/insomniacslk/dhcp/dhcpv4/server4"
+	"github.com/insomniacslk/dhcp/dhcpv4/server4/lease"
+	"github.com/insomniacslk/dhcp/internal/xsocket/vnet"
+)
+
+// TestServerExchangeOverVnet drives a full DORA exchange between
+// nclient4.Exchange and a server4.Server configured with WithTransport,
+// proving that a Transport passed to both ends is actually consulted for
+// every socket operation and that no root/CAP_NET_RAW is required.
+func TestServerExchangeOverVnet(t *testing.T) {
+	serverIface := net.Interface{Name: "eth-server", HardwareAddr: net.HardwareAddr{0x02, 0, 0, 0, 0, 1}}
+	clientIface := net.Interface{Name: "eth-client", HardwareAddr: net.HardwareAddr{0x02, 0, 0, 0, 0, 2}}
+	network := vnet.NewNetwork(serverIface, clientIface)
+
+	cfg := lease.PoolConfig{
+		ServerIP:   net.ParseIP("192.168.1.1"),
+		RangeStart: net.ParseIP("192.168.1.100"),
+		RangeEnd:   net.ParseIP("192.168.1.110"),
+		LeaseTime:  time.Hour,
+	}
+	pool, err := lease.NewPool(cfg)
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+	store, err := lease.NewMemoryStore(pool, "", 0)
+	if err != nil {
+		t.Fatalf("NewMemoryStore: %v", err)
+	}
+
+	s, err := server4.NewServer(serverIface.Name, nil, nil,
+		server4.WithTransport(network),
+		server4.WithLeaseHandler(store, cfg),
+	)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	defer s.Close()
+
+	go func() {
+		_ = s.Serve()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, ack, err := nclient4.Exchange(ctx, network, clientIface.Name, time.Second)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if ack.MessageType() != dhcpv4.MessageTypeAck {
+		t.Fatalf("got message type %s, want ACK", ack.MessageType())
+	}
+	if !pool.InRange(ack.YourIPAddr) {
+		t.Fatalf("leased address %s is outside the configured pool", ack.YourIPAddr)
+	}
+}
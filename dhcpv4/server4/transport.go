@@ -0,0 +1,57 @@
+// Copyright 2018 the u-root Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server4
+
+import "net"
+
+// Transport abstracts the network operations server4 needs to listen
+// for and reply to DHCP requests, so callers can plug in something other
+// than the std-net-backed, OS-specific default: an in-memory virtual
+// network for tests (see internal/xsocket/vnet), or a userspace TCP/IP
+// stack such as gVisor/netstack.
+//
+// It mirrors nclient4.Transport so a single implementation, such as
+// vnet.Network, can back both a client and a server under test.
+type Transport interface {
+	// ListenBroadcast opens a PacketConn bound to port on the named
+	// interface that can send and receive broadcast traffic.
+	ListenBroadcast(ifname string, port int) (net.PacketConn, error)
+
+	// Interfaces lists the network interfaces visible to this transport.
+	Interfaces() ([]net.Interface, error)
+
+	// InterfaceByName looks up a single interface by name.
+	InterfaceByName(ifname string) (*net.Interface, error)
+}
+
+// DefaultTransport is the Transport server4 uses when none is supplied
+// explicitly. It is backed by the standard library and the
+// platform-specific socket implementation for this OS.
+var DefaultTransport Transport = stdTransport{}
+
+type stdTransport struct{}
+
+func (stdTransport) ListenBroadcast(ifname string, port int) (net.PacketConn, error) {
+	return NewRawUDPConn(ifname, port)
+}
+
+func (stdTransport) Interfaces() ([]net.Interface, error) {
+	return net.Interfaces()
+}
+
+func (stdTransport) InterfaceByName(ifname string) (*net.Interface, error) {
+	return net.InterfaceByName(ifname)
+}
+
+// WithTransport sets the Transport a Server uses for its socket, in
+// place of the OS-specific default. This is primarily useful in tests, to
+// run a server against an in-memory virtual network instead of a real
+// socket that would otherwise require elevated privileges.
+func WithTransport(t Transport) ServerOpt {
+	return func(s *Server) error {
+		s.transport = t
+		return nil
+	}
+}
@@ -5,9 +5,10 @@ import (
 	"flag"
 	"log"
 	"net"
+	"time"
 
-	"github.com/insomniacslk/dhcp/dhcpv4"
 	"github.com/insomniacslk/dhcp/dhcpv4/server4"
+	"github.com/insomniacslk/dhcp/dhcpv4/server4/lease"
 )
 
 var (
@@ -21,82 +22,6 @@ var (
 	leaseTime  = flag.Int("lease", 3600, "Lease time in seconds")
 )
 
-// Simple IP allocator (not production-ready, just for testing)
-var nextIP net.IP
-
-func handler(conn net.PacketConn, peer net.Addr, m *dhcpv4.DHCPv4) {
-	log.Printf("Received %s from %s", m.MessageType(), peer)
-	log.Printf("  Client MAC: %s", m.ClientHWAddr)
-	log.Printf("  Transaction ID: %v", m.TransactionID)
-
-	var resp *dhcpv4.DHCPv4
-	var err error
-
-	switch m.MessageType() {
-	case dhcpv4.MessageTypeDiscover:
-		// Allocate an IP (simple incrementing, not production-ready)
-		if nextIP == nil {
-			nextIP = net.ParseIP(*rangeStart).To4()
-		}
-		allocatedIP := make(net.IP, 4)
-		copy(allocatedIP, nextIP)
-
-		resp, err = dhcpv4.NewReplyFromRequest(m,
-			dhcpv4.WithMessageType(dhcpv4.MessageTypeOffer),
-			dhcpv4.WithServerIP(net.ParseIP(*serverIP)),
-			dhcpv4.WithYourIP(allocatedIP),
-			dhcpv4.WithNetmask(net.IPMask(net.ParseIP(*mask).To4())),
-			dhcpv4.WithRouter(net.ParseIP(*router)),
-			dhcpv4.WithDNS(net.ParseIP(*dns)),
-			dhcpv4.WithLeaseTime(uint32(*leaseTime)),
-		)
-		if err != nil {
-			log.Printf("Error creating OFFER: %v", err)
-			return
-		}
-		log.Printf("Sending OFFER with IP %s", allocatedIP)
-
-	case dhcpv4.MessageTypeRequest:
-		// For simplicity, just ACK whatever was requested
-		requestedIP := m.RequestedIPAddress()
-		if requestedIP == nil {
-			requestedIP = m.YourIPAddr
-		}
-
-		resp, err = dhcpv4.NewReplyFromRequest(m,
-			dhcpv4.WithMessageType(dhcpv4.MessageTypeAck),
-			dhcpv4.WithServerIP(net.ParseIP(*serverIP)),
-			dhcpv4.WithYourIP(requestedIP),
-			dhcpv4.WithNetmask(net.IPMask(net.ParseIP(*mask).To4())),
-			dhcpv4.WithRouter(net.ParseIP(*router)),
-			dhcpv4.WithDNS(net.ParseIP(*dns)),
-			dhcpv4.WithLeaseTime(uint32(*leaseTime)),
-		)
-		if err != nil {
-			log.Printf("Error creating ACK: %v", err)
-			return
-		}
-		log.Printf("Sending ACK for IP %s", requestedIP)
-
-		// Increment next IP for next client
-		nextIP[3]++
-		if nextIP[3] > net.ParseIP(*rangeEnd).To4()[3] {
-			nextIP = net.ParseIP(*rangeStart).To4()
-		}
-
-	default:
-		log.Printf("Ignoring message type %s", m.MessageType())
-		return
-	}
-
-	if resp != nil {
-		log.Printf("Response: %s", resp.Summary())
-		if _, err := conn.WriteTo(resp.ToBytes(), peer); err != nil {
-			log.Printf("Error sending response: %v", err)
-		}
-	}
-}
-
 func main() {
 	flag.Parse()
 
@@ -108,12 +33,28 @@ func main() {
 	log.Printf("DNS: %s", *dns)
 	log.Printf("Lease Time: %d seconds", *leaseTime)
 
+	cfg := lease.PoolConfig{
+		ServerIP:   net.ParseIP(*serverIP),
+		RangeStart: net.ParseIP(*rangeStart),
+		RangeEnd:   net.ParseIP(*rangeEnd),
+		LeaseTime:  time.Duration(*leaseTime) * time.Second,
+	}
+	pool, err := lease.NewPool(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create lease pool: %v", err)
+	}
+	store, err := lease.NewMemoryStore(pool, "", 0)
+	if err != nil {
+		log.Fatalf("Failed to create lease store: %v", err)
+	}
+
 	laddr := net.UDPAddr{
 		IP:   net.ParseIP("0.0.0.0"),
 		Port: 67,
 	}
 
-	server, err := server4.NewServer(*ifname, &laddr, handler,
+	server, err := server4.NewServer(*ifname, &laddr, nil,
+		server4.WithLeaseHandler(store, cfg),
 		server4.WithSummaryLogger())
 	if err != nil {
 		log.Fatalf("Failed to create server: %v", err)
@@ -0,0 +1,221 @@
+// Package vnet implements a small in-memory virtual network, used to
+// drive a full DHCP DORA exchange between an nclient4 client and a
+// server4 server in tests without root or CAP_NET_RAW.
+package vnet
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// Network is a virtual LAN segment made up of named interfaces. A
+// Network's method set matches both nclient4.Transport and
+// server4.Transport, so it can be passed directly as either without this
+// package depending on either of them.
+type Network struct {
+	mu    sync.Mutex
+	ifs   map[string]net.Interface
+	conns map[string][]*Conn
+}
+
+// NewNetwork creates a virtual network with the given interfaces
+// pre-registered, each initially carrying no traffic.
+func NewNetwork(ifs ...net.Interface) *Network {
+	n := &Network{
+		ifs:   make(map[string]net.Interface, len(ifs)),
+		conns: make(map[string][]*Conn),
+	}
+	for _, ifi := range ifs {
+		n.ifs[ifi.Name] = ifi
+	}
+	return n
+}
+
+// Interfaces implements nclient4.Transport and server4.Transport.
+func (n *Network) Interfaces() ([]net.Interface, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	out := make([]net.Interface, 0, len(n.ifs))
+	for _, ifi := range n.ifs {
+		out = append(out, ifi)
+	}
+	return out, nil
+}
+
+// InterfaceByName implements nclient4.Transport and server4.Transport.
+func (n *Network) InterfaceByName(ifname string) (*net.Interface, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	ifi, ok := n.ifs[ifname]
+	if !ok {
+		return nil, fmt.Errorf("vnet: no such interface %q", ifname)
+	}
+	return &ifi, nil
+}
+
+// ListenBroadcast implements nclient4.Transport and server4.Transport. It
+// returns a Conn bound to ifname:port. A packet written to any Conn is
+// delivered to every Conn on the Network, whichever of its interfaces it
+// was opened on, whose port matches and whose address matches the
+// destination, or to all of them if the destination is a broadcast
+// address, emulating broadcast UDP on a shared LAN segment.
+func (n *Network) ListenBroadcast(ifname string, port int) (net.PacketConn, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, ok := n.ifs[ifname]; !ok {
+		return nil, fmt.Errorf("vnet: no such interface %q", ifname)
+	}
+	c := &Conn{
+		net:    n,
+		ifname: ifname,
+		addr:   &net.UDPAddr{IP: net.IPv4zero, Port: port},
+		inbox:  make(chan packet, 32),
+		closed: make(chan struct{}),
+	}
+	n.conns[ifname] = append(n.conns[ifname], c)
+	return c, nil
+}
+
+func (n *Network) deliver(ifname string, from net.Addr, dst *net.UDPAddr, b []byte) {
+	n.mu.Lock()
+	var conns []*Conn
+	for _, cs := range n.conns {
+		conns = append(conns, cs...)
+	}
+	n.mu.Unlock()
+
+	broadcast := dst.IP.IsUnspecified() || dst.IP.Equal(net.IPv4bcast)
+	msg := append([]byte(nil), b...)
+	for _, c := range conns {
+		if c.addr.Port != dst.Port {
+			continue
+		}
+		if !broadcast && !c.addr.IP.Equal(dst.IP) {
+			continue
+		}
+		select {
+		case c.inbox <- packet{from: from, b: msg}:
+		default: // drop on a full inbox, like a real, lossy link
+		}
+	}
+}
+
+type packet struct {
+	from net.Addr
+	b    []byte
+}
+
+// Conn is a net.PacketConn backed by a Network.
+type Conn struct {
+	net    *Network
+	ifname string
+	addr   *net.UDPAddr
+	inbox  chan packet
+	closed chan struct{}
+	once   sync.Once
+
+	mu        sync.Mutex
+	readDead  time.Time
+	writeDead time.Time
+}
+
+// ReadFrom implements net.PacketConn. It honors a deadline set by
+// SetDeadline/SetReadDeadline, returning a timeout error once it passes,
+// the same way a real blocking socket read would.
+func (c *Conn) ReadFrom(b []byte) (int, net.Addr, error) {
+	c.mu.Lock()
+	deadline := c.readDead
+	c.mu.Unlock()
+
+	var after <-chan time.Time
+	if !deadline.IsZero() {
+		if d := time.Until(deadline); d > 0 {
+			after = time.After(d)
+		} else {
+			after = time.After(0)
+		}
+	}
+
+	select {
+	case p := <-c.inbox:
+		return copy(b, p.b), p.from, nil
+	case <-c.closed:
+		return 0, nil, net.ErrClosed
+	case <-after:
+		return 0, nil, &net.OpError{Op: "read", Net: "vnet", Addr: c.addr, Err: errTimeout{}}
+	}
+}
+
+// WriteTo implements net.PacketConn. Delivery never blocks, so a deadline
+// set by SetDeadline/SetWriteDeadline only matters once it has already
+// passed by the time WriteTo is called.
+func (c *Conn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	udpAddr, ok := addr.(*net.UDPAddr)
+	if !ok {
+		return 0, errors.New("vnet: address must be a *net.UDPAddr")
+	}
+
+	c.mu.Lock()
+	deadline := c.writeDead
+	c.mu.Unlock()
+	if !deadline.IsZero() && !time.Now().Before(deadline) {
+		return 0, &net.OpError{Op: "write", Net: "vnet", Addr: udpAddr, Err: errTimeout{}}
+	}
+
+	select {
+	case <-c.closed:
+		return 0, net.ErrClosed
+	default:
+	}
+	c.net.deliver(c.ifname, c.addr, udpAddr, b)
+	return len(b), nil
+}
+
+// Close implements net.PacketConn.
+func (c *Conn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+// LocalAddr implements net.PacketConn.
+func (c *Conn) LocalAddr() net.Addr { return c.addr }
+
+// SetDeadline sets both the read and write deadlines; see SetReadDeadline
+// and SetWriteDeadline.
+func (c *Conn) SetDeadline(t time.Time) error {
+	c.SetReadDeadline(t)
+	c.SetWriteDeadline(t)
+	return nil
+}
+
+// SetReadDeadline sets the deadline for future ReadFrom calls. A ReadFrom
+// that is already blocked keeps the deadline that was in effect when it
+// started. A zero value disables the deadline.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.readDead = t
+	c.mu.Unlock()
+	return nil
+}
+
+// SetWriteDeadline sets the deadline for future WriteTo calls. A zero
+// value disables the deadline.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.writeDead = t
+	c.mu.Unlock()
+	return nil
+}
+
+// errTimeout implements net.Error for a deadline that has passed.
+type errTimeout struct{}
+
+func (errTimeout) Error() string   { return "vnet: i/o timeout" }
+func (errTimeout) Timeout() bool   { return true }
+func (errTimeout) Temporary() bool { return true }
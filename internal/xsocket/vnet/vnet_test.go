@@ -0,0 +1,82 @@
+package vnet
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestNetworkBroadcastDelivery(t *testing.T) {
+	n := NewNetwork(net.Interface{Name: "eth0"})
+
+	server, err := n.ListenBroadcast("eth0", 67)
+	if err != nil {
+		t.Fatalf("ListenBroadcast(server): %v", err)
+	}
+	defer server.Close()
+
+	client, err := n.ListenBroadcast("eth0", 68)
+	if err != nil {
+		t.Fatalf("ListenBroadcast(client): %v", err)
+	}
+	defer client.Close()
+
+	want := []byte("DISCOVER")
+	dst := &net.UDPAddr{IP: net.IPv4bcast, Port: 67}
+	if _, err := client.WriteTo(want, dst); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+
+	buf := make([]byte, 64)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		n, _, err := server.ReadFrom(buf)
+		if err != nil {
+			t.Errorf("ReadFrom: %v", err)
+			return
+		}
+		if got := string(buf[:n]); got != string(want) {
+			t.Errorf("ReadFrom got %q, want %q", got, want)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for broadcast delivery")
+	}
+}
+
+func TestConnReadFromHonorsReadDeadline(t *testing.T) {
+	n := NewNetwork(net.Interface{Name: "eth0"})
+	conn, err := n.ListenBroadcast("eth0", 68)
+	if err != nil {
+		t.Fatalf("ListenBroadcast: %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetReadDeadline(time.Now().Add(20 * time.Millisecond)); err != nil {
+		t.Fatalf("SetReadDeadline: %v", err)
+	}
+
+	buf := make([]byte, 64)
+	_, _, err = conn.ReadFrom(buf)
+	if err == nil {
+		t.Fatal("expected ReadFrom to time out waiting for a reply nothing ever sends, got nil error")
+	}
+	nerr, ok := err.(net.Error)
+	if !ok || !nerr.Timeout() {
+		t.Errorf("got error %v, want a net.Error reporting Timeout() == true", err)
+	}
+}
+
+func TestNetworkUnknownInterface(t *testing.T) {
+	n := NewNetwork(net.Interface{Name: "eth0"})
+	if _, err := n.ListenBroadcast("eth1", 67); err == nil {
+		t.Fatal("expected error listening on unknown interface, got nil")
+	}
+	if _, err := n.InterfaceByName("eth1"); err == nil {
+		t.Fatal("expected error resolving unknown interface, got nil")
+	}
+}